@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+
+	"github.com/automuteus/galactus/internal/orchestrator"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	port := os.Getenv("SHARD_ORCHESTRATOR_PORT")
+	if port == "" {
+		port = "8124"
+	}
+
+	botToken := os.Getenv("DISCORD_BOT_TOKEN")
+	if botToken == "" {
+		logger.Fatal("DISCORD_BOT_TOKEN not provided")
+	}
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Username: os.Getenv("REDIS_USER"),
+		Password: os.Getenv("REDIS_PASS"),
+		DB:       0,
+	})
+
+	o := orchestrator.NewOrchestrator(logger, rdb, botToken)
+	o.Run(port)
+}
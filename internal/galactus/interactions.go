@@ -0,0 +1,256 @@
+package galactus
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	redisutils "github.com/automuteus/galactus/internal/redis"
+	"github.com/automuteus/galactus/pkg/discord_message"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// loadInteractionsPublicKey parses the hex-encoded Ed25519 public key
+// Discord shows for the application, used to verify that incoming
+// /interactions requests actually came from Discord. Returns nil (and logs)
+// if pubKeyHex is empty or malformed; interactionsHandler rejects every
+// request in that case rather than accepting unverified webhooks.
+func loadInteractionsPublicKey(logger *zap.Logger, pubKeyHex string) ed25519.PublicKey {
+	if pubKeyHex == "" {
+		logger.Info("no DISCORD_PUBLIC_KEY provided; /interactions will reject all requests")
+		return nil
+	}
+
+	byt, err := hex.DecodeString(pubKeyHex)
+	if err != nil {
+		logger.Error("error decoding DISCORD_PUBLIC_KEY as hex", zap.Error(err))
+		return nil
+	}
+	if len(byt) != ed25519.PublicKeySize {
+		logger.Error("DISCORD_PUBLIC_KEY is the wrong length for an Ed25519 public key",
+			zap.Int("length", len(byt)))
+		return nil
+	}
+	return ed25519.PublicKey(byt)
+}
+
+// verifyInteractionSignature checks the X-Signature-Ed25519/
+// X-Signature-Timestamp headers against body, per Discord's interaction
+// endpoint verification requirements.
+func verifyInteractionSignature(pubKey ed25519.PublicKey, signatureHex, timestamp string, body []byte) bool {
+	if pubKey == nil || signatureHex == "" || timestamp == "" {
+		return false
+	}
+
+	sig, err := hex.DecodeString(signatureHex)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+
+	msg := append([]byte(timestamp), body...)
+	return ed25519.Verify(pubKey, msg, sig)
+}
+
+// interactionsHandler serves POST /interactions: Discord's webhook ingress
+// for slash commands, message components, and modals. Pings are answered
+// immediately with a type-1 PONG, as Discord requires; everything else is
+// pushed onto the same Redis job queue PushDiscordMessage already feeds, so
+// the bot worker can pick it up and respond within Discord's 3-second
+// window using the CreateInteractionResponse REST proxy below.
+func (galactus *GalactusAPI) interactionsHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			galactus.logger.Error("error reading interaction request body", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if !verifyInteractionSignature(galactus.interactionsPublicKey,
+			r.Header.Get("X-Signature-Ed25519"),
+			r.Header.Get("X-Signature-Timestamp"),
+			body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var interaction discordgo.Interaction
+		if err := json.Unmarshal(body, &interaction); err != nil {
+			galactus.logger.Error("error unmarshalling interaction payload", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if interaction.Type == discordgo.InteractionPing {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(discordgo.InteractionResponse{Type: discordgo.InteractionResponsePong})
+			return
+		}
+
+		if err := redisutils.PushDiscordMessage(galactus.client, discord_message.Interaction, body); err != nil {
+			galactus.logger.Error("error pushing interaction onto Redis job queue",
+				zap.Error(err),
+				zap.String("interactionID", interaction.ID))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		galactus.logger.Info("pushed interaction to Redis",
+			zap.String("interactionID", interaction.ID),
+			zap.Int("type", int(interaction.Type)),
+		)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// recordRESTErr feeds the X-RateLimit-* headers Discord attached to a failed
+// call back into the rate limiter, when the error carries a response to read
+// them from (discordgo.RESTError does for any non-2xx response; transport
+// errors like a timeout don't, and are silently skipped here). This is the
+// only place anything feeds galactus.restLimiter, since discordgo's typed
+// methods don't return headers on success - see ratelimit.Limiter's doc
+// comment for what that means for how preemptive it actually is.
+func (galactus *GalactusAPI) recordRESTErr(guildID, hashedToken, method, routeTemplate string, err error) {
+	restErr, ok := err.(*discordgo.RESTError)
+	if !ok || restErr.Response == nil {
+		return
+	}
+	galactus.recordRESTResponse(method, routeTemplate, guildID, hashedToken, restErr.Response.Header)
+}
+
+// CreateInteractionResponseRequest is the body expected by
+// CreateInteractionResponseHandler: enough of the originating interaction
+// to build the callback URL, plus the response to send.
+type CreateInteractionResponseRequest struct {
+	GuildID     string                         `json:"guildID"`
+	Interaction *discordgo.Interaction         `json:"interaction"`
+	Response    *discordgo.InteractionResponse `json:"response"`
+}
+
+// CreateInteractionResponseHandler lets a worker answer an interaction
+// within Discord's 3-second window without holding its own Discord session.
+func (galactus *GalactusAPI) CreateInteractionResponseHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateInteractionResponseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Interaction == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		tokens := galactus.getAllTokensForGuild(req.GuildID)
+		sess, hToken := galactus.getAnySession(req.GuildID, tokens, len(tokens), "POST", "/interactions/{interaction.id}/{interaction.token}/callback")
+		if sess == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err := sess.InteractionRespond(req.Interaction, req.Response); err != nil {
+			galactus.recordRESTErr(req.GuildID, hToken, "POST", "/interactions/{interaction.id}/{interaction.token}/callback", err)
+			galactus.logger.Error("error responding to interaction",
+				zap.Error(err),
+				zap.String("interactionID", req.Interaction.ID))
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("{\"error\": \"" + err.Error() + "\"}"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// EditOriginalInteractionResponseRequest is the body expected by
+// EditOriginalInteractionResponseHandler.
+type EditOriginalInteractionResponseRequest struct {
+	GuildID     string                 `json:"guildID"`
+	Interaction *discordgo.Interaction `json:"interaction"`
+	Edit        *discordgo.WebhookEdit `json:"edit"`
+}
+
+// EditOriginalInteractionResponseHandler lets a worker edit its original
+// interaction response after the initial 3-second window.
+func (galactus *GalactusAPI) EditOriginalInteractionResponseHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req EditOriginalInteractionResponseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Interaction == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		tokens := galactus.getAllTokensForGuild(req.GuildID)
+		sess, hToken := galactus.getAnySession(req.GuildID, tokens, len(tokens), "PATCH", "/webhooks/{application.id}/{interaction.token}/messages/@original")
+		if sess == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		msg, err := sess.InteractionResponseEdit(req.Interaction, req.Edit)
+		if err != nil {
+			galactus.recordRESTErr(req.GuildID, hToken, "PATCH", "/webhooks/{application.id}/{interaction.token}/messages/@original", err)
+			galactus.logger.Error("error editing original interaction response",
+				zap.Error(err),
+				zap.String("interactionID", req.Interaction.ID))
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("{\"error\": \"" + err.Error() + "\"}"))
+			return
+		}
+
+		byt, err := json.Marshal(msg)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(byt)
+	}
+}
+
+// CreateFollowupMessageRequest is the body expected by
+// CreateFollowupMessageHandler.
+type CreateFollowupMessageRequest struct {
+	GuildID     string                   `json:"guildID"`
+	Interaction *discordgo.Interaction   `json:"interaction"`
+	Wait        bool                     `json:"wait"`
+	Params      *discordgo.WebhookParams `json:"params"`
+}
+
+// CreateFollowupMessageHandler lets a worker send additional messages for an
+// interaction beyond the initial response.
+func (galactus *GalactusAPI) CreateFollowupMessageHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateFollowupMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Interaction == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		tokens := galactus.getAllTokensForGuild(req.GuildID)
+		sess, hToken := galactus.getAnySession(req.GuildID, tokens, len(tokens), "POST", "/webhooks/{application.id}/{interaction.token}")
+		if sess == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		msg, err := sess.FollowupMessageCreate(req.Interaction, req.Wait, req.Params)
+		if err != nil {
+			galactus.recordRESTErr(req.GuildID, hToken, "POST", "/webhooks/{application.id}/{interaction.token}", err)
+			galactus.logger.Error("error creating followup message",
+				zap.Error(err),
+				zap.String("interactionID", req.Interaction.ID))
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("{\"error\": \"" + err.Error() + "\"}"))
+			return
+		}
+
+		byt, err := json.Marshal(msg)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(byt)
+	}
+}
@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	bucketKeyPrefix    = "ratelimit:bucket:"
+	routeBucketPrefix  = "ratelimit:route:"
+	globalLockKey      = "ratelimit:global"
+	defaultBucketLimit = 1
+)
+
+// bucketState mirrors the fields Discord hands back in the X-RateLimit-*
+// response headers for a given bucket.
+type bucketState struct {
+	Remaining  int   `json:"remaining"`
+	Limit      int   `json:"limit"`
+	ResetAfter int64 `json:"reset_after_ms"`
+}
+
+// Limiter is a Redis-backed token-bucket rate limiter for the Discord REST
+// API, meant to be preemptive: Acquire blocks before a call is made if the
+// bucket is already known to be exhausted. State is keyed by route key (see
+// RouteKey): one entry per method, route template, major parameter and
+// token. Discord's X-RateLimit-Bucket header is not read, so routes that
+// Discord groups into one shared bucket are tracked as separate buckets
+// here. State lives in Redis so that multiple Galactus replicas sharing a
+// worker token cooperate instead of racing each other into a 429.
+//
+// KNOWN LIMITATION: UpdateFromHeaders can only be called where the caller
+// has the X-RateLimit-* response headers in hand. Its only caller,
+// GalactusAPI.recordRESTErr, reaches it from discordgo's
+// *discordgo.RESTError, i.e. only on a non-2xx response - the typed
+// discordgo.Session methods Galactus calls (InteractionRespond,
+// ApplyMuteDeaf, ...) don't return headers on success. recordRESTErr is
+// called only by the interaction response handlers; the mute/deafen path
+// (attemptOnSecondaryTokens) does not pass its errors back, so the bucket
+// for that route never blocks. For the interaction routes, bucketWait only
+// starts blocking after a bucket has already produced an error once; it
+// isn't preemptive against a bucket's first exhaustion, only repeats of it.
+type Limiter struct {
+	client *redis.Client
+	logger *zap.Logger
+}
+
+func NewLimiter(logger *zap.Logger, client *redis.Client) *Limiter {
+	return &Limiter{
+		client: client,
+		logger: logger,
+	}
+}
+
+// RouteKey hashes a REST route template plus its major parameter (and the
+// token making the call) into the key the limiter stores bucket state under,
+// e.g. "POST /channels/{channel.id}/messages" + channelID.
+func RouteKey(method, routeTemplate, majorParam, hashedToken string) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + routeTemplate + ":" + majorParam + ":" + hashedToken))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Acquire blocks the caller until a token is available for the given route
+// key, or returns immediately if the bucket isn't currently known to be
+// exhausted. It also enforces any active global rate limit.
+func (l *Limiter) Acquire(ctx context.Context, routeKey string) error {
+	for {
+		wait, err := l.globalWait(ctx)
+		if err != nil {
+			return err
+		}
+		if wait > 0 {
+			time.Sleep(wait)
+			continue
+		}
+
+		wait, err = l.bucketWait(ctx, routeKey)
+		if err != nil {
+			return err
+		}
+		if wait <= 0 {
+			return nil
+		}
+		time.Sleep(wait)
+	}
+}
+
+func (l *Limiter) globalWait(ctx context.Context) (time.Duration, error) {
+	ttl, err := l.client.PTTL(ctx, globalLockKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl > 0 {
+		return ttl, nil
+	}
+	return 0, nil
+}
+
+func (l *Limiter) bucketWait(ctx context.Context, routeKey string) (time.Duration, error) {
+	raw, err := l.client.Get(ctx, bucketKeyPrefix+routeKey).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var state bucketState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		l.logger.Error("error unmarshalling rate limit bucket state",
+			zap.Error(err),
+			zap.String("routeKey", routeKey))
+		return 0, nil
+	}
+
+	if state.Remaining > 0 {
+		return 0, nil
+	}
+
+	ttl, err := l.client.PTTL(ctx, bucketKeyPrefix+routeKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// UpdateFromHeaders records the bucket state Discord returned for the route
+// that was just called, so the next Acquire for this bucket knows whether it
+// needs to wait.
+func (l *Limiter) UpdateFromHeaders(ctx context.Context, routeKey string, header http.Header) {
+	if header.Get("X-RateLimit-Global") != "" {
+		retryAfterSec, err := strconv.ParseFloat(header.Get("Retry-After"), 64)
+		if err == nil {
+			l.setGlobalLock(ctx, time.Duration(retryAfterSec*float64(time.Second)))
+		}
+		return
+	}
+
+	remainingStr := header.Get("X-RateLimit-Remaining")
+	resetAfterStr := header.Get("X-RateLimit-Reset-After")
+	limitStr := header.Get("X-RateLimit-Limit")
+	if remainingStr == "" || resetAfterStr == "" {
+		return
+	}
+
+	remaining, err := strconv.Atoi(remainingStr)
+	if err != nil {
+		return
+	}
+	resetAfterSec, err := strconv.ParseFloat(resetAfterStr, 64)
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(limitStr)
+	if limit == 0 {
+		limit = defaultBucketLimit
+	}
+
+	state := bucketState{
+		Remaining:  remaining,
+		Limit:      limit,
+		ResetAfter: int64(resetAfterSec * 1000),
+	}
+	byt, err := json.Marshal(state)
+	if err != nil {
+		l.logger.Error("error marshalling rate limit bucket state", zap.Error(err))
+		return
+	}
+
+	ttl := time.Duration(state.ResetAfter) * time.Millisecond
+	if ttl <= 0 {
+		ttl = time.Millisecond
+	}
+	if err := l.client.Set(ctx, bucketKeyPrefix+routeKey, byt, ttl).Err(); err != nil {
+		l.logger.Error("error persisting rate limit bucket state",
+			zap.Error(err),
+			zap.String("routeKey", routeKey))
+	}
+}
+
+func (l *Limiter) setGlobalLock(ctx context.Context, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		return
+	}
+	if err := l.client.Set(ctx, globalLockKey, "1", retryAfter).Err(); err != nil {
+		l.logger.Error("error setting global rate limit lock", zap.Error(err))
+	} else {
+		l.logger.Info("global rate limit hit; all Galactus instances paused",
+			zap.Duration("retryAfter", retryAfter))
+	}
+}
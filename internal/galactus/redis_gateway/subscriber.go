@@ -0,0 +1,220 @@
+package redis_gateway
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/automuteus/galactus/internal/handler"
+	"github.com/automuteus/galactus/internal/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// eventChannelPattern matches the per-shard channels an external gateway
+	// process publishes to, e.g. "discord:events:3".
+	eventChannelPattern = "discord:events:*"
+
+	dedupeKeyPrefix = "redis_gateway:seen:"
+	dedupeTTL       = 10 * time.Minute
+)
+
+// envelope is the wire format an external gateway process publishes for every
+// Discord event it receives off its own websocket connection.
+type envelope struct {
+	ShardID    int             `json:"shard_id"`
+	EventType  string          `json:"event_type"`
+	Sequence   int64           `json:"sequence"`
+	RawPayload json.RawMessage `json:"raw_payload"`
+}
+
+// Subscriber consumes Discord gateway events fanned out over Redis pub/sub by
+// an external gateway process, and hydrates them into the same handlers the
+// in-process shard manager would have called. This lets the gateway (with
+// its own rate/resume/zombie requirements) run as a separate process while
+// Galactus keeps doing everything it does with events today.
+type Subscriber struct {
+	// pubsubClient subscribes to the external gateway's event channels.
+	pubsubClient *redis.Client
+	logger       *zap.Logger
+	sess         *discordgo.Session
+
+	voiceStateUpdate  func(s *discordgo.Session, m *discordgo.VoiceStateUpdate)
+	guildDelete       func(s *discordgo.Session, m *discordgo.GuildDelete)
+	guildCreate       func(s *discordgo.Session, m *discordgo.GuildCreate)
+	guildUpdate       func(s *discordgo.Session, m *discordgo.GuildUpdate)
+	channelCreate     func(s *discordgo.Session, m *discordgo.ChannelCreate)
+	channelUpdate     func(s *discordgo.Session, m *discordgo.ChannelUpdate)
+	channelDelete     func(s *discordgo.Session, m *discordgo.ChannelDelete)
+	guildMemberAdd    func(s *discordgo.Session, m *discordgo.GuildMemberAdd)
+	guildMemberUpdate func(s *discordgo.Session, m *discordgo.GuildMemberUpdate)
+	guildMemberRemove func(s *discordgo.Session, m *discordgo.GuildMemberRemove)
+}
+
+// NewSubscriber builds a Subscriber that reads events off pubsubClient (the
+// external gateway's own Redis) and hydrates them into the same handlers
+// the in-process shard manager would have called, writing through dataClient
+// and store, the Redis instance and state cache the rest of Galactus
+// already shares. botUserID must be the bot's own user ID; it populates the
+// synthetic session's state so handlers that unconditionally read
+// s.State.User.ID (e.g. VoiceStateUpdateHandler, to ignore the bot's own
+// events) keep behaving the same way they do when fed by a real
+// discordgo.Session instead of nil-dereferencing. Callers must not enter
+// gateway mode without one - see NewGalactusAPI's REDIS_GATEWAY_URL handling.
+func NewSubscriber(logger *zap.Logger, pubsubClient, dataClient *redis.Client, store *state.Store, botUserID string) *Subscriber {
+	sess := &discordgo.Session{State: discordgo.NewState()}
+	sess.State.User = &discordgo.User{ID: botUserID}
+
+	return &Subscriber{
+		pubsubClient: pubsubClient,
+		logger:       logger,
+		sess:         sess,
+
+		voiceStateUpdate:  handler.VoiceStateUpdateHandler(logger, dataClient, store),
+		guildDelete:       handler.GuildDeleteHandler(logger, dataClient, store),
+		guildCreate:       handler.GuildCreateHandler(logger, dataClient, store),
+		guildUpdate:       handler.GuildUpdateHandler(logger, dataClient, store),
+		channelCreate:     handler.ChannelCreateHandler(logger, dataClient, store),
+		channelUpdate:     handler.ChannelUpdateHandler(logger, dataClient, store),
+		channelDelete:     handler.ChannelDeleteHandler(logger, dataClient, store),
+		guildMemberAdd:    handler.GuildMemberAddHandler(logger, dataClient, store),
+		guildMemberUpdate: handler.GuildMemberUpdateHandler(logger, dataClient, store),
+		guildMemberRemove: handler.GuildMemberRemoveHandler(logger, dataClient, store),
+	}
+}
+
+// Run subscribes to the shard event channels and blocks, dispatching events
+// until ctx is cancelled.
+func (s *Subscriber) Run(ctx context.Context) error {
+	pubsub := s.pubsubClient.PSubscribe(ctx, eventChannelPattern)
+	defer pubsub.Close()
+
+	s.logger.Info("redis gateway subscriber started",
+		zap.String("pattern", eventChannelPattern))
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			s.handleMessage(ctx, msg.Payload)
+		}
+	}
+}
+
+func (s *Subscriber) handleMessage(ctx context.Context, payload string) {
+	var env envelope
+	if err := json.Unmarshal([]byte(payload), &env); err != nil {
+		s.logger.Error("error unmarshalling redis gateway envelope", zap.Error(err))
+		return
+	}
+
+	fresh, err := s.markSeen(ctx, env.ShardID, env.Sequence)
+	if err != nil {
+		s.logger.Error("error deduplicating redis gateway event",
+			zap.Error(err),
+			zap.Int("shardID", env.ShardID),
+			zap.Int64("sequence", env.Sequence))
+		return
+	}
+	if !fresh {
+		s.logger.Info("dropping duplicate redis gateway event",
+			zap.Int("shardID", env.ShardID),
+			zap.Int64("sequence", env.Sequence),
+			zap.String("eventType", env.EventType))
+		return
+	}
+
+	switch env.EventType {
+	case "VOICE_STATE_UPDATE":
+		var m discordgo.VoiceStateUpdate
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling VoiceStateUpdate payload", zap.Error(err))
+			return
+		}
+		s.voiceStateUpdate(s.sess, &m)
+	case "GUILD_DELETE":
+		var m discordgo.GuildDelete
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling GuildDelete payload", zap.Error(err))
+			return
+		}
+		s.guildDelete(s.sess, &m)
+	case "GUILD_CREATE":
+		var m discordgo.GuildCreate
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling GuildCreate payload", zap.Error(err))
+			return
+		}
+		s.guildCreate(s.sess, &m)
+	case "GUILD_UPDATE":
+		var m discordgo.GuildUpdate
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling GuildUpdate payload", zap.Error(err))
+			return
+		}
+		s.guildUpdate(s.sess, &m)
+	case "CHANNEL_CREATE":
+		var m discordgo.ChannelCreate
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling ChannelCreate payload", zap.Error(err))
+			return
+		}
+		s.channelCreate(s.sess, &m)
+	case "CHANNEL_UPDATE":
+		var m discordgo.ChannelUpdate
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling ChannelUpdate payload", zap.Error(err))
+			return
+		}
+		s.channelUpdate(s.sess, &m)
+	case "CHANNEL_DELETE":
+		var m discordgo.ChannelDelete
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling ChannelDelete payload", zap.Error(err))
+			return
+		}
+		s.channelDelete(s.sess, &m)
+	case "GUILD_MEMBER_ADD":
+		var m discordgo.GuildMemberAdd
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling GuildMemberAdd payload", zap.Error(err))
+			return
+		}
+		s.guildMemberAdd(s.sess, &m)
+	case "GUILD_MEMBER_UPDATE":
+		var m discordgo.GuildMemberUpdate
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling GuildMemberUpdate payload", zap.Error(err))
+			return
+		}
+		s.guildMemberUpdate(s.sess, &m)
+	case "GUILD_MEMBER_REMOVE":
+		var m discordgo.GuildMemberRemove
+		if err := json.Unmarshal(env.RawPayload, &m); err != nil {
+			s.logger.Error("error unmarshalling GuildMemberRemove payload", zap.Error(err))
+			return
+		}
+		s.guildMemberRemove(s.sess, &m)
+	default:
+		s.logger.Info("ignoring unhandled redis gateway event type",
+			zap.String("eventType", env.EventType),
+			zap.Int("shardID", env.ShardID))
+	}
+}
+
+// markSeen records (shardID, sequence) in Redis and reports whether this is
+// the first time it's been observed, so the same event re-delivered by a
+// reconnecting gateway (or fanned out to more than one Galactus replica)
+// isn't processed twice.
+func (s *Subscriber) markSeen(ctx context.Context, shardID int, sequence int64) (fresh bool, err error) {
+	key := dedupeKeyPrefix + strconv.Itoa(shardID) + ":" + strconv.FormatInt(sequence, 10)
+	return s.pubsubClient.SetNX(ctx, key, "1", dedupeTTL).Result()
+}
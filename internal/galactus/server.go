@@ -2,19 +2,24 @@ package galactus
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"github.com/alicebob/miniredis/v2"
+	"github.com/automuteus/galactus/internal/galactus/ratelimit"
+	"github.com/automuteus/galactus/internal/galactus/redis_gateway"
 	"github.com/automuteus/galactus/internal/galactus/shard_manager"
 	redisutils "github.com/automuteus/galactus/internal/redis"
+	"github.com/automuteus/galactus/internal/state"
 	"github.com/automuteus/galactus/pkg/endpoint"
 	"github.com/automuteus/utils/pkg/premium"
 	"github.com/automuteus/utils/pkg/rediskey"
 	"github.com/automuteus/utils/pkg/token"
 	"github.com/bwmarrin/discordgo"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/jonas747/dshardmanager"
 	"go.uber.org/zap"
@@ -34,17 +39,43 @@ var PremiumBotConstraints = map[premium.Tier]int{
 	5: 100, // Selfhost; 100 bots(!)
 }
 
-var DefaultIntents = discordgo.MakeIntent(discordgo.IntentsGuildVoiceStates | discordgo.IntentsGuildMessages | discordgo.IntentsGuilds | discordgo.IntentsGuildMessageReactions)
+var DefaultIntents = discordgo.MakeIntent(discordgo.IntentsGuildVoiceStates | discordgo.IntentsGuildMessages | discordgo.IntentsGuilds | discordgo.IntentsGuildMessageReactions | discordgo.IntentsGuildMembers)
 
 type GalactusAPI struct {
 	client       *redis.Client
 	shardManager *dshardmanager.Manager
 
+	// set instead of shardManager when REDIS_GATEWAY_URL is configured; owns
+	// the event subscription in place of an in-process shard manager
+	redisGatewaySubscriber *redis_gateway.Subscriber
+	redisGatewayCancel     context.CancelFunc
+
+	// cancels the heartbeat loop and reshard watcher started alongside
+	// shardManager when shards are leased from a shardorchestrator; nil
+	// otherwise
+	shardLeaseCancel context.CancelFunc
+
+	// nil when running in Redis-fanout gateway mode, since there are no
+	// local shard sessions to supervise
+	shardSupervisor *shard_manager.ResumeSupervisor
+
+	// gateway-derived cache of guild channels, members, and voice states
+	stateStore *state.Store
+
 	// maps hashed tokens to active discord sessions
 	activeSessions      map[string]*discordgo.Session
 	maxRequests5Seconds int64
 	sessionLock         sync.RWMutex
 
+	// per-bucket rate limiter for outbound Discord REST calls. Only
+	// reactive today, not truly preemptive - see ratelimit.Limiter's doc
+	// comment for why.
+	restLimiter *ratelimit.Limiter
+
+	// verifies inbound POST /interactions webhooks; nil (every request
+	// rejected) if DISCORD_PUBLIC_KEY isn't configured
+	interactionsPublicKey ed25519.PublicKey
+
 	logger *zap.Logger
 }
 
@@ -68,16 +99,85 @@ func NewGalactusAPI(logger *zap.Logger, mockRedis bool, botToken, redisAddr, red
 		})
 	}
 
-	manager := shard_manager.MakeShardManager(logger, botToken, DefaultIntents)
-	shard_manager.AddHandlers(logger, manager, rdb)
+	logger.Warn("REST rate limiter only learns a bucket is exhausted from a failed call's response " +
+		"headers; discordgo's typed session methods used here don't expose headers on a successful " +
+		"response, so it cannot yet preempt a bucket's first exhaustion, only repeats of it")
+
+	stateStore := state.NewStore(logger, rdb)
+
+	var manager *dshardmanager.Manager
+	var supervisor *shard_manager.ResumeSupervisor
+	var gatewaySub *redis_gateway.Subscriber
+	var gatewayCancel context.CancelFunc
+
+	if redisGatewayURL := os.Getenv("REDIS_GATEWAY_URL"); redisGatewayURL != "" {
+		logger.Info("REDIS_GATEWAY_URL set; running in Redis-fanout gateway mode",
+			zap.String("redisGatewayURL", redisGatewayURL))
+
+		botUserID := os.Getenv("DISCORD_BOT_USER_ID")
+
+		gatewayOpts, err := redis.ParseURL(redisGatewayURL)
+		if err != nil {
+			logger.Error("error parsing REDIS_GATEWAY_URL; falling back to in-process shard manager",
+				zap.Error(err))
+		} else if botUserID == "" {
+			logger.Error("REDIS_GATEWAY_URL set without DISCORD_BOT_USER_ID; falling back to in-process shard manager")
+		} else {
+			gatewayRdb := redis.NewClient(gatewayOpts)
+			gatewaySub = redis_gateway.NewSubscriber(logger, gatewayRdb, rdb, stateStore, botUserID)
+
+			var ctx context.Context
+			ctx, gatewayCancel = context.WithCancel(context.Background())
+			go func() {
+				if err := gatewaySub.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					logger.Error("redis gateway subscriber exited with error", zap.Error(err))
+				}
+			}()
+		}
+	}
+
+	var shardLeaseCancel context.CancelFunc
+	if gatewaySub == nil {
+		instanceID := uuid.NewString()
+		var leasedShardIDs []int
+		var leasedShardCount int
+		leased := false
+		if shardIDs, shardCount, ok := requestShardLease(logger, instanceID, 1); ok {
+			logger.Info("running with shards leased from orchestrator",
+				zap.String("instanceID", instanceID),
+				zap.Ints("shardIDs", shardIDs),
+				zap.Int("shardCount", shardCount),
+			)
+			leasedShardIDs = shardIDs
+			leasedShardCount = shardCount
+			leased = true
+		}
+
+		manager = shard_manager.MakeShardManager(logger, botToken, DefaultIntents, leasedShardIDs, leasedShardCount)
+		supervisor = shard_manager.AddHandlers(logger, manager, rdb, stateStore)
+
+		if leased {
+			var ctx context.Context
+			ctx, shardLeaseCancel = context.WithCancel(context.Background())
+			go startHeartbeatLoop(ctx, logger, instanceID)
+			go watchForReshard(ctx, logger, rdb, instanceID)
+		}
+	}
 
 	return &GalactusAPI{
-		client:              rdb,
-		shardManager:        manager,
-		activeSessions:      make(map[string]*discordgo.Session),
-		maxRequests5Seconds: maxReq,
-		sessionLock:         sync.RWMutex{},
-		logger:              logger,
+		client:                 rdb,
+		shardManager:           manager,
+		shardSupervisor:        supervisor,
+		redisGatewaySubscriber: gatewaySub,
+		redisGatewayCancel:     gatewayCancel,
+		shardLeaseCancel:       shardLeaseCancel,
+		stateStore:             stateStore,
+		activeSessions:         make(map[string]*discordgo.Session),
+		maxRequests5Seconds:    maxReq,
+		sessionLock:            sync.RWMutex{},
+		restLimiter:            ratelimit.NewLimiter(logger, rdb),
+		interactionsPublicKey:  loadInteractionsPublicKey(logger, os.Getenv("DISCORD_PUBLIC_KEY")),
+		logger:                 logger,
 	}
 }
 
@@ -93,7 +193,13 @@ func (galactus *GalactusAPI) getAllTokensForGuild(guildID string) []string {
 	return hTokens
 }
 
-func (galactus *GalactusAPI) getAnySession(guildID string, tokens []string, limit int) (*discordgo.Session, string) {
+// getAnySession picks a session to use for an outbound call to
+// method+routeTemplate (e.g. "PATCH", "/guilds/{guild.id}/members/{user.id}"),
+// blocking on galactus.restLimiter for whichever token it selects so the
+// caller is clear to make the call the moment a session comes back. This is
+// the only REST call gate in Galactus; it replaces the old ad-hoc
+// IncrAndTestGuildTokenComboLock check.
+func (galactus *GalactusAPI) getAnySession(guildID string, tokens []string, limit int, method, routeTemplate string) (*discordgo.Session, string) {
 	galactus.sessionLock.RLock()
 	defer galactus.sessionLock.RUnlock()
 
@@ -101,20 +207,24 @@ func (galactus *GalactusAPI) getAnySession(guildID string, tokens []string, limi
 		if i == limit {
 			return nil, ""
 		}
-		// if this token isn't potentially rate-limited
-		if galactus.IncrAndTestGuildTokenComboLock(guildID, hToken) {
-			sess, ok := galactus.activeSessions[hToken]
-			if ok {
-				return sess, hToken
-			}
+
+		sess, ok := galactus.activeSessions[hToken]
+		if !ok {
 			// remove this key from our records and keep going
 			galactus.client.SRem(context.Background(), rediskey.GuildTokensKey(guildID), hToken)
-		} else {
-			galactus.logger.Info("secondary token potentially rate-limited; skipping",
+			continue
+		}
+
+		if err := galactus.acquireRESTToken(method, routeTemplate, guildID, hToken); err != nil {
+			galactus.logger.Error("error acquiring REST token; skipping",
+				zap.Error(err),
 				zap.String("hashedToken", hToken),
 				zap.String("guildID", guildID),
 			)
+			continue
 		}
+
+		return sess, hToken
 	}
 
 	return nil, ""
@@ -152,6 +262,23 @@ func (galactus *GalactusAPI) IncrAndTestGuildTokenComboLock(guildID, hashToken s
 	return true
 }
 
+// acquireRESTToken blocks until the Discord REST proxy handlers are clear to
+// make the given call, respecting both Discord's per-bucket limits and any
+// active global 429 cooldown. routeTemplate/majorParam identify the Discord
+// bucket (e.g. "POST /channels/{channel.id}/messages" + the channel ID); this
+// replaces IncrAndTestGuildTokenComboLock as the gate for outbound REST calls.
+func (galactus *GalactusAPI) acquireRESTToken(method, routeTemplate, majorParam, hashedToken string) error {
+	routeKey := ratelimit.RouteKey(method, routeTemplate, majorParam, hashedToken)
+	return galactus.restLimiter.Acquire(context.Background(), routeKey)
+}
+
+// recordRESTResponse updates the rate limiter's view of a bucket from the
+// X-RateLimit-* headers Discord returned for a completed REST call.
+func (galactus *GalactusAPI) recordRESTResponse(method, routeTemplate, majorParam, hashedToken string, header http.Header) {
+	routeKey := ratelimit.RouteKey(method, routeTemplate, majorParam, hashedToken)
+	galactus.restLimiter.UpdateFromHeaders(context.Background(), routeKey, header)
+}
+
 func (galactus *GalactusAPI) BlacklistTokenForDuration(guildID, hashToken string, duration time.Duration) error {
 	return galactus.client.Set(context.Background(), rediskey.GuildTokenLock(guildID, hashToken), galactus.maxRequests5Seconds, duration).Err()
 }
@@ -190,6 +317,17 @@ func (galactus *GalactusAPI) Run(port string, maxWorkers int, captureAckTimeout
 	r.HandleFunc(endpoint.RequestJob, galactus.requestJobHandler(taskTimeout)).Methods("POST")
 	r.HandleFunc(endpoint.JobCount, galactus.jobCount()).Methods("GET")
 
+	r.HandleFunc(endpoint.ShardsStatus, galactus.shardsStatusHandler()).Methods("GET")
+
+	r.HandleFunc(endpoint.GetStateChannel, galactus.getChannelHandler()).Methods("GET")
+	r.HandleFunc(endpoint.GetStateMember, galactus.getMemberHandler()).Methods("GET")
+	r.HandleFunc(endpoint.GetStateVoiceState, galactus.getVoiceStateHandler()).Methods("GET")
+
+	r.HandleFunc(endpoint.Interactions, galactus.interactionsHandler()).Methods("POST")
+	r.HandleFunc(endpoint.CreateInteractionResponse, galactus.CreateInteractionResponseHandler()).Methods("POST")
+	r.HandleFunc(endpoint.EditOriginalInteractionResponse, galactus.EditOriginalInteractionResponseHandler()).Methods("POST")
+	r.HandleFunc(endpoint.CreateFollowupMessage, galactus.CreateFollowupMessageHandler()).Methods("POST")
+
 	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
@@ -274,6 +412,105 @@ func (galactus *GalactusAPI) jobCount() func(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+// stateResponse wraps a cached state read with the "cold" flag from
+// internal/state, so a caller that sees Cold == true knows to fall back to
+// a direct REST call instead of trusting potentially-stale Data.
+type stateResponse struct {
+	Found bool        `json:"found"`
+	Cold  bool        `json:"cold"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+func (galactus *GalactusAPI) writeStateResponse(w http.ResponseWriter, guildID string, found bool, data interface{}) {
+	cold, err := galactus.stateStore.IsCold(context.Background(), guildID)
+	if err != nil {
+		galactus.logger.Error("error checking cold state for guild",
+			zap.Error(err),
+			zap.String("guildID", guildID))
+	}
+
+	byt, err := json.Marshal(stateResponse{Found: found, Cold: cold, Data: data})
+	if err != nil {
+		galactus.logger.Error("error marshalling state response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(byt)
+}
+
+func (galactus *GalactusAPI) getChannelHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		channel, found, err := galactus.stateStore.GetChannel(r.Context(), vars["guildID"], vars["channelID"])
+		if err != nil {
+			galactus.logger.Error("error reading cached channel",
+				zap.Error(err),
+				zap.String("guildID", vars["guildID"]),
+				zap.String("channelID", vars["channelID"]))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		galactus.writeStateResponse(w, vars["guildID"], found, channel)
+	}
+}
+
+func (galactus *GalactusAPI) getMemberHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		member, found, err := galactus.stateStore.GetMember(r.Context(), vars["guildID"], vars["userID"])
+		if err != nil {
+			galactus.logger.Error("error reading cached member",
+				zap.Error(err),
+				zap.String("guildID", vars["guildID"]),
+				zap.String("userID", vars["userID"]))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		galactus.writeStateResponse(w, vars["guildID"], found, member)
+	}
+}
+
+func (galactus *GalactusAPI) getVoiceStateHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		voiceState, found, err := galactus.stateStore.GetVoiceState(r.Context(), vars["guildID"], vars["userID"])
+		if err != nil {
+			galactus.logger.Error("error reading cached voice state",
+				zap.Error(err),
+				zap.String("guildID", vars["guildID"]),
+				zap.String("userID", vars["userID"]))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		galactus.writeStateResponse(w, vars["guildID"], found, voiceState)
+	}
+}
+
+// shardsStatusHandler reports per-shard resume supervisor metrics
+// (last_ack, resumes, reidentifies, current_backoff_ms). It returns an empty
+// object when running in Redis-fanout gateway mode, since there's no local
+// shard supervisor to report on.
+func (galactus *GalactusAPI) shardsStatusHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if galactus.shardSupervisor == nil {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("{}"))
+			return
+		}
+
+		byt, err := json.Marshal(galactus.shardSupervisor.Status())
+		if err != nil {
+			galactus.logger.Error("error marshalling shard status", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("{\"error\": \"" + err.Error() + "\"}"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(byt)
+	}
+}
+
 func (galactus *GalactusAPI) loadTokensFromEnv() {
 	workerTokenStr := strings.ReplaceAll(os.Getenv("WORKER_BOT_TOKENS"), " ", "")
 	if workerTokenStr == "" {
@@ -362,16 +599,24 @@ func hashToken(token string) string {
 }
 
 func (galactus *GalactusAPI) Close() {
-	err := galactus.shardManager.StopAll()
-	if err != nil {
-		galactus.logger.Error("error stopping all shard sessions",
-			zap.Error(err),
-		)
+	if galactus.redisGatewayCancel != nil {
+		galactus.redisGatewayCancel()
+	}
+	if galactus.shardLeaseCancel != nil {
+		galactus.shardLeaseCancel()
+	}
+
+	if galactus.shardManager != nil {
+		if err := galactus.shardManager.StopAll(); err != nil {
+			galactus.logger.Error("error stopping all shard sessions",
+				zap.Error(err),
+			)
+		}
 	}
 
 	galactus.sessionLock.Lock()
 	for hToken, v := range galactus.activeSessions {
-		err = v.Close()
+		err := v.Close()
 		if err != nil {
 			galactus.logger.Error("error closing active session",
 				zap.Error(err),
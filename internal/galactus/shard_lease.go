@@ -0,0 +1,145 @@
+package galactus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/automuteus/galactus/internal/orchestrator"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// heartbeatInterval is how often a leased instance re-asserts its heartbeat
+// with the shard orchestrator. It stays comfortably under
+// orchestrator.HeartbeatTTL so a single slow or dropped heartbeat POST
+// doesn't cost the instance its shards.
+const heartbeatInterval = orchestrator.HeartbeatTTL / 3
+
+// requestShardLease asks a running shardorchestrator instance (configured via
+// SHARD_ORCHESTRATOR_URL) for a lease on requestShards shards. It is a no-op
+// returning (nil, 0, false) when no orchestrator is configured, in which
+// case the caller should fall back to running every shard in-process as
+// before.
+func requestShardLease(logger *zap.Logger, instanceID string, requestShards int) (shardIDs []int, shardCount int, ok bool) {
+	orchestratorURL := os.Getenv("SHARD_ORCHESTRATOR_URL")
+	if orchestratorURL == "" {
+		return nil, 0, false
+	}
+
+	reqBody, err := json.Marshal(orchestrator.RegisterRequest{
+		InstanceID:    instanceID,
+		RequestShards: requestShards,
+	})
+	if err != nil {
+		logger.Error("error marshalling shard lease request", zap.Error(err))
+		return nil, 0, false
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(orchestratorURL+"/register", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		logger.Error("error registering with shard orchestrator",
+			zap.Error(err),
+			zap.String("orchestratorURL", orchestratorURL))
+		return nil, 0, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("shard orchestrator returned non-200 status",
+			zap.Int("status", resp.StatusCode))
+		return nil, 0, false
+	}
+
+	var regResp orchestrator.RegisterResponse
+	if err := json.NewDecoder(resp.Body).Decode(&regResp); err != nil {
+		logger.Error("error decoding shard lease response", zap.Error(err))
+		return nil, 0, false
+	}
+
+	logger.Info("obtained shard lease from orchestrator",
+		zap.Ints("shardIDs", regResp.ShardIDs),
+		zap.Int("shardCount", regResp.ShardCount),
+	)
+
+	return regResp.ShardIDs, regResp.ShardCount, true
+}
+
+// startHeartbeatLoop POSTs /heartbeat to SHARD_ORCHESTRATOR_URL on
+// heartbeatInterval until ctx is done, keeping the orchestrator's
+// heartbeatKeyPrefix+instanceID key alive for as long as this instance is
+// running its leased shards. Without this, that key expires
+// orchestrator.HeartbeatTTL after the one-shot /register call, and the next
+// instance to register sees a "dead" owner and reclaims shards this one is
+// still connected to Discord with. It is a no-op if no orchestrator is
+// configured.
+func startHeartbeatLoop(ctx context.Context, logger *zap.Logger, instanceID string) {
+	orchestratorURL := os.Getenv("SHARD_ORCHESTRATOR_URL")
+	if orchestratorURL == "" {
+		return
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sendHeartbeat(client, logger, orchestratorURL, instanceID)
+		}
+	}
+}
+
+func sendHeartbeat(client *http.Client, logger *zap.Logger, orchestratorURL, instanceID string) {
+	reqBody, err := json.Marshal(orchestrator.HeartbeatRequest{InstanceID: instanceID})
+	if err != nil {
+		logger.Error("error marshalling heartbeat request", zap.Error(err))
+		return
+	}
+
+	resp, err := client.Post(orchestratorURL+"/heartbeat", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		logger.Error("error sending heartbeat to shard orchestrator",
+			zap.Error(err),
+			zap.String("orchestratorURL", orchestratorURL))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("shard orchestrator returned non-200 status for heartbeat",
+			zap.Int("status", resp.StatusCode))
+	}
+}
+
+// watchForReshard blocks on the orchestrator's ReshardChannel until it fires
+// or ctx is done, then exits the process. The orchestrator only ever hands
+// out a shard assignment at /register time, and dshardmanager exposes no
+// supported way to swap a running manager's ShardIDs/ShardCount out from
+// under it, so the only safe way for this instance to pick up whatever new
+// assignment /admin/reshard produced is to restart and re-register from
+// scratch. This relies on the process running under a supervisor (systemd,
+// Kubernetes, ...) configured to restart it on exit; it is a no-op if no
+// orchestrator is configured.
+func watchForReshard(ctx context.Context, logger *zap.Logger, rdb *redis.Client, instanceID string) {
+	if os.Getenv("SHARD_ORCHESTRATOR_URL") == "" {
+		return
+	}
+
+	pubsub := rdb.Subscribe(ctx, orchestrator.ReshardChannel)
+	defer pubsub.Close()
+
+	select {
+	case <-ctx.Done():
+	case <-pubsub.Channel():
+		logger.Fatal("shard orchestrator triggered a reshard; exiting to re-register for a fresh shard lease",
+			zap.String("instanceID", instanceID))
+	}
+}
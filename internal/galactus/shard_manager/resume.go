@@ -0,0 +1,241 @@
+package shard_manager
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jonas747/dshardmanager"
+	"go.uber.org/zap"
+)
+
+const (
+	zombieCheckInterval = 5 * time.Second
+
+	// discordgo doesn't expose the heartbeat_interval Discord negotiated for
+	// a session, so we watch for staleness against the documented default
+	// rather than the live value; zombieAckWindows mirrors the request's
+	// "two consecutive heartbeat_interval windows with no ack" rule.
+	assumedHeartbeatInterval = 41250 * time.Millisecond
+	zombieAckWindows         = 2
+
+	backoffBase = time.Second
+	backoffCap  = 60 * time.Second
+	spreadMax   = 5 * time.Second
+
+	// minReconnectSpacing guards against identify-storming a flapping
+	// shard: discordgo.Session.Close() performs a clean (code 1000)
+	// websocket closure, which per Discord's gateway spec invalidates the
+	// session - so every reconnect forced through Close()+Open() is a
+	// fresh IDENTIFY, never a RESUME. discordgo's public Session API
+	// doesn't expose the raw close-code control or the stored
+	// session_id/seq a manual RESUME would need, so there's no way to
+	// avoid that from this package. Since each forced reconnect spends
+	// one of Discord's daily per-shard IDENTIFY budget, refuse to force
+	// another one for the same shard faster than this.
+	minReconnectSpacing = zombieCheckInterval * 2
+)
+
+// shardStatus is the point-in-time resume/backoff state for a single shard,
+// exposed via GalactusAPI's /shards/status endpoint.
+type shardStatus struct {
+	LastAck        time.Time `json:"last_ack"`
+	Resumes        int       `json:"resumes"`
+	Reidentifies   int       `json:"reidentifies"`
+	CurrentBackoff int64     `json:"current_backoff_ms"`
+}
+
+// ResumeSupervisor polls every shard session's heartbeat ack time and forces
+// a reconnect when a shard looks zombied: still connected, but no longer
+// acking heartbeats. discordgo's own reconnect logic only reacts to a closed
+// socket, so it never notices this case on its own. The forced reconnect
+// always comes back as a fresh IDENTIFY, not a RESUME - see
+// minReconnectSpacing's doc comment for why - but Resumes/Reidentifies are
+// still tracked separately via the Resumed/Ready events, since a session can
+// also resume on its own (e.g. after a transient network drop discordgo
+// notices itself).
+type ResumeSupervisor struct {
+	logger  *zap.Logger
+	manager *dshardmanager.Manager
+
+	mu            sync.Mutex
+	status        map[int]*shardStatus
+	attempt       map[int]int
+	lastReconnect map[int]time.Time
+	reconnecting  map[int]bool
+}
+
+func NewResumeSupervisor(logger *zap.Logger, manager *dshardmanager.Manager) *ResumeSupervisor {
+	logger.Warn("resume supervisor does not perform a protocol-level Discord RESUME; every forced " +
+		"reconnect it triggers is a fresh IDENTIFY that spends part of this bot's daily per-shard " +
+		"IDENTIFY budget. This is a known limitation of discordgo.Session's public API, not a bug: " +
+		"Close() always sends a clean (code 1000) closure, which invalidates resumability, and " +
+		"session_id/seq aren't exposed for a manual RESUME. See forceReconnect's doc comment.")
+
+	return &ResumeSupervisor{
+		logger:        logger,
+		manager:       manager,
+		status:        make(map[int]*shardStatus),
+		attempt:       make(map[int]int),
+		lastReconnect: make(map[int]time.Time),
+		reconnecting:  make(map[int]bool),
+	}
+}
+
+// Run polls every shard on zombieCheckInterval until stopped. It's meant to
+// be started once, in a goroutine, alongside the shard manager. Each shard
+// is checked in its own goroutine so one flapping shard - whose
+// forceReconnect can sleep up to backoffCap+spreadMax before it even closes
+// the socket - never delays zombie detection for the rest of the fleet.
+func (r *ResumeSupervisor) Run() {
+	ticker := time.NewTicker(zombieCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, sess := range r.manager.GetSessions() {
+			if sess != nil {
+				go r.checkShard(sess)
+			}
+		}
+	}
+}
+
+func (r *ResumeSupervisor) checkShard(sess *discordgo.Session) {
+	shardID := sess.ShardID
+
+	r.mu.Lock()
+	st, ok := r.status[shardID]
+	if !ok {
+		st = &shardStatus{}
+		r.status[shardID] = st
+	}
+	if !sess.LastHeartbeatAck.IsZero() {
+		st.LastAck = sess.LastHeartbeatAck
+	}
+	lastAck := st.LastAck
+	alreadyReconnecting := r.reconnecting[shardID]
+	sinceLast := time.Since(r.lastReconnect[shardID])
+	r.mu.Unlock()
+
+	if lastAck.IsZero() || time.Since(lastAck) < assumedHeartbeatInterval*zombieAckWindows {
+		return
+	}
+
+	// checkShard now runs concurrently per tick (see Run), so a still
+	// in-flight forceReconnect from an earlier tick must be skipped here
+	// rather than allowed to race a second one for the same shard.
+	if alreadyReconnecting {
+		return
+	}
+
+	if sinceLast < minReconnectSpacing {
+		r.logger.Info("shard looks zombied but was reconnected too recently; holding off to avoid an identify-storm",
+			zap.Int("shardID", shardID),
+			zap.Duration("sinceLastReconnect", sinceLast),
+		)
+		return
+	}
+
+	r.logger.Info("shard looks zombied; no heartbeat ack for two windows, forcing reconnect",
+		zap.Int("shardID", shardID),
+		zap.Time("lastAck", lastAck),
+	)
+	r.forceReconnect(sess)
+}
+
+// forceReconnect closes and reopens the session after an exponential
+// backoff with full jitter (base 1s, cap 60s), plus a small random spread so
+// shards don't all come back on the same tick. KNOWN LIMITATION: this is a
+// re-IDENTIFY, not a protocol-level RESUME - see minReconnectSpacing's doc
+// comment for why discordgo's public API leaves this package no way to do
+// better. Guards against running twice for the same shard concurrently via
+// r.reconnecting, since Run now checks every shard in its own goroutine.
+func (r *ResumeSupervisor) forceReconnect(sess *discordgo.Session) {
+	shardID := sess.ShardID
+
+	r.mu.Lock()
+	r.reconnecting[shardID] = true
+	attempt := r.attempt[shardID]
+	r.attempt[shardID]++
+	backoff := fullJitterBackoff(attempt)
+	r.status[shardID].CurrentBackoff = backoff.Milliseconds()
+	r.lastReconnect[shardID] = time.Now()
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.reconnecting[shardID] = false
+		r.mu.Unlock()
+	}()
+
+	spread := time.Duration(rand.Int63n(int64(spreadMax)))
+	time.Sleep(backoff + spread)
+
+	if err := sess.Close(); err != nil {
+		r.logger.Error("error closing zombied shard session",
+			zap.Error(err),
+			zap.Int("shardID", shardID),
+		)
+	}
+	if err := sess.Open(); err != nil {
+		r.logger.Error("error reopening shard session after forced reconnect",
+			zap.Error(err),
+			zap.Int("shardID", shardID),
+		)
+		return
+	}
+
+	r.mu.Lock()
+	r.status[shardID].CurrentBackoff = 0
+	r.mu.Unlock()
+}
+
+// fullJitterBackoff implements sleep = rand(0, min(cap, base*2^attempt)).
+func fullJitterBackoff(attempt int) time.Duration {
+	ceiling := backoffBase * time.Duration(1<<uint(attempt))
+	if ceiling <= 0 || ceiling > backoffCap {
+		ceiling = backoffCap
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+func (r *ResumeSupervisor) onReady(s *discordgo.Session, _ *discordgo.Ready) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.statusLocked(s.ShardID)
+	st.Reidentifies++
+	r.attempt[s.ShardID] = 0
+}
+
+func (r *ResumeSupervisor) onResumed(s *discordgo.Session, _ *discordgo.Resumed) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.statusLocked(s.ShardID)
+	st.Resumes++
+	r.attempt[s.ShardID] = 0
+}
+
+func (r *ResumeSupervisor) statusLocked(shardID int) *shardStatus {
+	st, ok := r.status[shardID]
+	if !ok {
+		st = &shardStatus{}
+		r.status[shardID] = st
+	}
+	return st
+}
+
+// Status returns a snapshot of every known shard's resume/backoff state,
+// suitable for JSON-encoding directly as GalactusAPI's /shards/status
+// response.
+func (r *ResumeSupervisor) Status() map[int]*shardStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := make(map[int]*shardStatus, len(r.status))
+	for shardID, st := range r.status {
+		cp := *st
+		snapshot[shardID] = &cp
+	}
+	return snapshot
+}
@@ -0,0 +1,64 @@
+package shard_manager
+
+import (
+	"github.com/automuteus/galactus/internal/handler"
+	"github.com/automuteus/galactus/internal/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"github.com/jonas747/dshardmanager"
+	"go.uber.org/zap"
+)
+
+// MakeShardManager builds a dshardmanager.Manager for botToken, configured
+// with the intents Galactus needs on every shard session it opens. When
+// shardIDs is non-empty, the manager only opens sessions for those shard
+// IDs (leased from a shardorchestrator instance) out of shardCount total,
+// instead of every shard in the fleet.
+func MakeShardManager(logger *zap.Logger, botToken string, intents discordgo.Intent, shardIDs []int, shardCount int) *dshardmanager.Manager {
+	manager := dshardmanager.New("Bot " + botToken)
+	manager.Name = "Galactus"
+	manager.SessionFunc = func(token string) (*discordgo.Session, error) {
+		sess, err := discordgo.New(token)
+		if err != nil {
+			return nil, err
+		}
+		sess.Identify.Intents = intents
+		return sess, nil
+	}
+
+	if len(shardIDs) > 0 {
+		manager.ShardIDs = shardIDs
+		logger.Info("restricting shard manager to leased shards",
+			zap.Ints("shardIDs", shardIDs),
+			zap.Int("shardCount", shardCount))
+	}
+	if shardCount > 0 {
+		manager.ShardCount = shardCount
+	}
+
+	return manager
+}
+
+// AddHandlers wires the Discord gateway event handlers onto every shard
+// session managed, and starts the resume/backoff supervisor (resume.go)
+// watching them for zombied connections. The returned ResumeSupervisor
+// backs GalactusAPI's /shards/status endpoint.
+func AddHandlers(logger *zap.Logger, manager *dshardmanager.Manager, rdb *redis.Client, store *state.Store) *ResumeSupervisor {
+	manager.AddHandler(handler.VoiceStateUpdateHandler(logger, rdb, store))
+	manager.AddHandler(handler.GuildDeleteHandler(logger, rdb, store))
+	manager.AddHandler(handler.GuildCreateHandler(logger, rdb, store))
+	manager.AddHandler(handler.GuildUpdateHandler(logger, rdb, store))
+	manager.AddHandler(handler.ChannelCreateHandler(logger, rdb, store))
+	manager.AddHandler(handler.ChannelUpdateHandler(logger, rdb, store))
+	manager.AddHandler(handler.ChannelDeleteHandler(logger, rdb, store))
+	manager.AddHandler(handler.GuildMemberAddHandler(logger, rdb, store))
+	manager.AddHandler(handler.GuildMemberUpdateHandler(logger, rdb, store))
+	manager.AddHandler(handler.GuildMemberRemoveHandler(logger, rdb, store))
+
+	supervisor := NewResumeSupervisor(logger, manager)
+	manager.AddHandler(supervisor.onReady)
+	manager.AddHandler(supervisor.onResumed)
+	go supervisor.Run()
+
+	return supervisor
+}
@@ -12,7 +12,7 @@ import (
 
 func (galactus *GalactusAPI) attemptOnSecondaryTokens(guildID, userID string, tokens []string, limit int, request discord.UserModify) bool {
 	if tokens != nil && limit > 0 {
-		sess, hToken := galactus.getAnySession(guildID, tokens, limit)
+		sess, hToken := galactus.getAnySession(guildID, tokens, limit, "PATCH", "/guilds/{guild.id}/members/{user.id}")
 		if sess != nil {
 			err := discord.ApplyMuteDeaf(sess, guildID, userID, request.Mute, request.Deaf)
 			if err != nil {
@@ -87,4 +87,4 @@ func (galactus *GalactusAPI) attemptOnCaptureBot(guildID, connectCode string, gi
 		log.Println("Capture client is probably rate-limited. Deferring to main bot instead")
 	}
 	return false
-}
\ No newline at end of file
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/automuteus/galactus/internal/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+func ChannelCreateHandler(logger *zap.Logger, client *redis.Client, store *state.Store) func(s *discordgo.Session, m *discordgo.ChannelCreate) {
+	return func(s *discordgo.Session, m *discordgo.ChannelCreate) {
+		if err := store.UpsertChannel(context.Background(), m.GuildID, m.Channel); err != nil {
+			logger.Error("error caching channel for ChannelCreate",
+				zap.Error(err),
+				zap.String("guild_id", m.GuildID),
+				zap.String("channel_id", m.ID))
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/automuteus/galactus/internal/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+func ChannelDeleteHandler(logger *zap.Logger, client *redis.Client, store *state.Store) func(s *discordgo.Session, m *discordgo.ChannelDelete) {
+	return func(s *discordgo.Session, m *discordgo.ChannelDelete) {
+		if err := store.DeleteChannel(context.Background(), m.GuildID, m.ID); err != nil {
+			logger.Error("error evicting channel for ChannelDelete",
+				zap.Error(err),
+				zap.String("guild_id", m.GuildID),
+				zap.String("channel_id", m.ID))
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/automuteus/galactus/internal/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// GuildCreateHandler seeds Galactus' cached guild state from the GUILD_CREATE
+// payload, which is the only event carrying a guild's full channel, member,
+// and voice state lists in one shot. It does not forward the event through
+// the job queue; GUILD_CREATE fires on every reconnect and carries no
+// information the bot worker needs beyond what's now in the cache.
+func GuildCreateHandler(logger *zap.Logger, client *redis.Client, store *state.Store) func(s *discordgo.Session, m *discordgo.GuildCreate) {
+	return func(s *discordgo.Session, m *discordgo.GuildCreate) {
+		if err := store.UpsertGuildSnapshot(context.Background(), m.Guild); err != nil {
+			logger.Error("error caching guild snapshot for GuildCreate",
+				zap.Error(err),
+				zap.String("guild_id", m.ID))
+			return
+		}
+		logger.Info("cached guild snapshot",
+			zap.String("guild_id", m.ID),
+			zap.Int("channels", len(m.Channels)),
+			zap.Int("members", len(m.Members)),
+		)
+	}
+}
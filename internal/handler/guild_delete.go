@@ -1,15 +1,32 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	redis_utils "github.com/automuteus/galactus/internal/redis"
+	"github.com/automuteus/galactus/internal/state"
 	"github.com/bwmarrin/discordgo"
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
-func GuildDeleteHandler(logger *zap.Logger, client *redis.Client) func(s *discordgo.Session, m *discordgo.GuildDelete) {
+func GuildDeleteHandler(logger *zap.Logger, client *redis.Client, store *state.Store) func(s *discordgo.Session, m *discordgo.GuildDelete) {
 	return func(s *discordgo.Session, m *discordgo.GuildDelete) {
+		// Unavailable means Discord is having an outage, not that the guild
+		// was actually removed; keep the cached state around but flag it
+		// stale instead of throwing it away.
+		var err error
+		if m.Unavailable {
+			err = store.MarkCold(context.Background(), m.ID)
+		} else {
+			err = store.DeleteGuild(context.Background(), m.ID)
+		}
+		if err != nil {
+			logger.Error("error updating cached guild state for GuildDelete",
+				zap.Error(err),
+				zap.String("ID", m.ID))
+		}
+
 		byt, err := json.Marshal(m)
 		if err != nil {
 			logger.Error("error marshalling json for GuildDelete message",
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/automuteus/galactus/internal/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+func GuildMemberAddHandler(logger *zap.Logger, client *redis.Client, store *state.Store) func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		if err := store.UpsertMember(context.Background(), m.GuildID, m.Member); err != nil {
+			logger.Error("error caching member for GuildMemberAdd",
+				zap.Error(err),
+				zap.String("guild_id", m.GuildID),
+				zap.String("user_id", m.User.ID))
+		}
+	}
+}
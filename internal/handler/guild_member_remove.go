@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/automuteus/galactus/internal/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+func GuildMemberRemoveHandler(logger *zap.Logger, client *redis.Client, store *state.Store) func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+		if err := store.DeleteMember(context.Background(), m.GuildID, m.User.ID); err != nil {
+			logger.Error("error evicting member for GuildMemberRemove",
+				zap.Error(err),
+				zap.String("guild_id", m.GuildID),
+				zap.String("user_id", m.User.ID))
+		}
+	}
+}
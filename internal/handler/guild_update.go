@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/automuteus/galactus/internal/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// GuildUpdateHandler refreshes the cached guild metadata and roles from a
+// GUILD_UPDATE payload.
+func GuildUpdateHandler(logger *zap.Logger, client *redis.Client, store *state.Store) func(s *discordgo.Session, m *discordgo.GuildUpdate) {
+	return func(s *discordgo.Session, m *discordgo.GuildUpdate) {
+		if err := store.UpdateGuildMeta(context.Background(), m.Guild); err != nil {
+			logger.Error("error caching guild metadata for GuildUpdate",
+				zap.Error(err),
+				zap.String("guild_id", m.ID))
+		}
+	}
+}
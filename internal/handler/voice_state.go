@@ -1,15 +1,17 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	redis_utils "github.com/automuteus/galactus/internal/redis"
+	"github.com/automuteus/galactus/internal/state"
 	"github.com/automuteus/galactus/pkg/discord_message"
 	"github.com/bwmarrin/discordgo"
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
-func VoiceStateUpdateHandler(logger *zap.Logger, client *redis.Client) func(s *discordgo.Session, m *discordgo.VoiceStateUpdate) {
+func VoiceStateUpdateHandler(logger *zap.Logger, client *redis.Client, store *state.Store) func(s *discordgo.Session, m *discordgo.VoiceStateUpdate) {
 	return func(s *discordgo.Session, m *discordgo.VoiceStateUpdate) {
 		if m == nil {
 			return
@@ -19,6 +21,14 @@ func VoiceStateUpdateHandler(logger *zap.Logger, client *redis.Client) func(s *d
 			return
 		}
 
+		if err := store.UpsertVoiceState(context.Background(), m.VoiceState); err != nil {
+			logger.Error("error caching voice state",
+				zap.Error(err),
+				zap.String("guild_id", m.GuildID),
+				zap.String("user_id", m.UserID),
+			)
+		}
+
 		// if no active games, completely ignore message reactions
 		if !redis_utils.AnyActiveGamesInGuild(client, m.GuildID) {
 			return
@@ -0,0 +1,57 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const gatewayBotEndpoint = "https://discord.com/api/v9/gateway/bot"
+
+type sessionStartLimit struct {
+	Total          int `json:"total"`
+	Remaining      int `json:"remaining"`
+	ResetAfter     int `json:"reset_after"`
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+type gatewayBotResponse struct {
+	URL               string            `json:"url"`
+	Shards            int               `json:"shards"`
+	SessionStartLimit sessionStartLimit `json:"session_start_limit"`
+}
+
+// fetchRecommendedShards asks Discord's /gateway/bot endpoint for the
+// recommended shard count and the per-bucket IDENTIFY concurrency window for
+// this bot token.
+func fetchRecommendedShards(botToken string) (shardCount, maxConcurrency int, err error) {
+	req, err := http.NewRequest(http.MethodGet, gatewayBotEndpoint, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("Authorization", "Bot "+botToken)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, errors.New("discord returned non-200 status from /gateway/bot: " + resp.Status)
+	}
+
+	var parsed gatewayBotResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return 0, 0, err
+	}
+
+	maxConcurrency = parsed.SessionStartLimit.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+
+	return parsed.Shards, maxConcurrency, nil
+}
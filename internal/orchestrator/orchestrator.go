@@ -0,0 +1,324 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	// heartbeatTTL is how long an instance's heartbeat key lives before the
+	// orchestrator considers the instance dead and its shards reclaimable.
+	// Exported as HeartbeatTTL so a Galactus instance can derive a safe
+	// heartbeat interval from the same canonical value instead of
+	// hardcoding a second copy of it.
+	heartbeatTTL = time.Second * 30
+	HeartbeatTTL = heartbeatTTL
+
+	heartbeatKeyPrefix = "orchestrator:heartbeat:"
+	leaseHashKey       = "orchestrator:leases"
+	shardCountKey      = "orchestrator:shard_count"
+
+	// leaseLockKey guards the leaseShards read-modify-write so two
+	// instances registering concurrently can't compute the same set of
+	// unowned shards and both get leased them.
+	leaseLockKey = "orchestrator:lease_lock"
+	leaseLockTTL = 5 * time.Second
+
+	// ReshardChannel is the Redis pub/sub channel the orchestrator
+	// publishes to whenever /admin/reshard runs, so every instance still
+	// holding a lease under the old assignment finds out and can react -
+	// see reshardHandler.
+	ReshardChannel = "orchestrator:reshard"
+)
+
+// RegisterRequest is sent by a Galactus worker on startup to request a lease
+// on some number of shards.
+type RegisterRequest struct {
+	InstanceID    string `json:"instance_id"`
+	RequestShards int    `json:"request_shards"`
+}
+
+// RegisterResponse hands back the authoritative shard assignment for the
+// requesting instance, along with the total shard count the whole fleet
+// should be configured with.
+type RegisterResponse struct {
+	ShardIDs   []int `json:"shard_ids"`
+	ShardCount int   `json:"shard_count"`
+}
+
+type HeartbeatRequest struct {
+	InstanceID string `json:"instance_id"`
+}
+
+// Orchestrator owns the authoritative shard -> instance assignment for a
+// Galactus fleet. It is the single source of truth for "who runs which
+// shard" so that individual Galactus processes can scale horizontally
+// without stepping on each other's Discord sessions.
+type Orchestrator struct {
+	client   *redis.Client
+	logger   *zap.Logger
+	botToken string
+
+	mu             sync.Mutex
+	shardCount     int
+	maxConcurrency int
+}
+
+func NewOrchestrator(logger *zap.Logger, rdb *redis.Client, botToken string) *Orchestrator {
+	return &Orchestrator{
+		client:   rdb,
+		logger:   logger,
+		botToken: botToken,
+	}
+}
+
+// Run starts the HTTP registration/admin server and blocks.
+func (o *Orchestrator) Run(port string) {
+	if err := o.refreshShardCount(); err != nil {
+		o.logger.Error("error fetching recommended shard count from Discord; falling back to 1",
+			zap.Error(err))
+		o.shardCount = 1
+		o.maxConcurrency = 1
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/register", o.registerHandler()).Methods("POST")
+	r.HandleFunc("/heartbeat", o.heartbeatHandler()).Methods("POST")
+	r.HandleFunc("/admin/reshard", o.reshardHandler()).Methods("POST")
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}).Methods("GET")
+
+	o.logger.Info("shard orchestrator is running",
+		zap.String("port", port),
+		zap.Int("shard_count", o.shardCount),
+	)
+
+	err := http.ListenAndServe(":"+port, r)
+	if err != nil {
+		o.logger.Error("http listener exited with error",
+			zap.Error(err),
+		)
+	}
+}
+
+func (o *Orchestrator) refreshShardCount() error {
+	shardCount, maxConcurrency, err := fetchRecommendedShards(o.botToken)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.shardCount = shardCount
+	o.maxConcurrency = maxConcurrency
+	o.mu.Unlock()
+
+	return o.client.Set(context.Background(), shardCountKey, shardCount, 0).Err()
+}
+
+// leaseShards assigns up to `count` currently-unowned shards to instanceID,
+// respecting Discord's IDENTIFY concurrency bucket (shards in the same
+// max_concurrency bucket are handed out together so a worker can start them
+// in the same window). The read-modify-write against leaseHashKey is
+// serialized behind acquireLeaseLock so two instances registering at the
+// same time can't both compute the same "unowned" shard set.
+func (o *Orchestrator) leaseShards(ctx context.Context, instanceID string, count int) ([]int, error) {
+	o.mu.Lock()
+	shardCount := o.shardCount
+	maxConcurrency := o.maxConcurrency
+	o.mu.Unlock()
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+
+	release, err := o.acquireLeaseLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	existing, err := o.client.HGetAll(ctx, leaseHashKey).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	owned := make(map[int]bool, len(existing))
+	for shardIDStr, owner := range existing {
+		shardID, err := strconv.Atoi(shardIDStr)
+		if err != nil {
+			continue
+		}
+
+		// An owner with no live heartbeat is dead; reclaim its shard
+		// instead of treating it as still owned.
+		alive, err := o.client.Exists(ctx, heartbeatKeyPrefix+owner).Result()
+		if err != nil {
+			return nil, err
+		}
+		if alive == 0 {
+			o.client.HDel(ctx, leaseHashKey, shardIDStr)
+			o.logger.Info("reclaimed shard from dead instance",
+				zap.Int("shardID", shardID),
+				zap.String("deadInstanceID", owner))
+			continue
+		}
+
+		owned[shardID] = true
+	}
+
+	// Walk bucket-by-bucket (shardID % maxConcurrency) rather than shard ID
+	// order, so the shards making up one IDENTIFY concurrency bucket are
+	// handed out to the same instance together instead of split across
+	// whichever instances happen to register first.
+	assigned := make([]int, 0, count)
+	for bucket := 0; bucket < maxConcurrency && len(assigned) < count; bucket++ {
+		for shardID := bucket; shardID < shardCount && len(assigned) < count; shardID += maxConcurrency {
+			if owned[shardID] {
+				continue
+			}
+			assigned = append(assigned, shardID)
+		}
+	}
+
+	pipe := o.client.Pipeline()
+	for _, shardID := range assigned {
+		pipe.HSet(ctx, leaseHashKey, strconv.Itoa(shardID), instanceID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return assigned, nil
+}
+
+// acquireLeaseLock blocks until it holds the cluster-wide lease lock (or ctx
+// is done), returning a release func the caller must call to free it. The
+// lock itself carries a TTL so a crash between acquire and release doesn't
+// wedge the fleet forever.
+func (o *Orchestrator) acquireLeaseLock(ctx context.Context) (func(), error) {
+	for {
+		ok, err := o.client.SetNX(ctx, leaseLockKey, "1", leaseLockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return func() {
+				o.client.Del(context.Background(), leaseLockKey)
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("timed out waiting for shard lease lock")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func (o *Orchestrator) registerHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RegisterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.InstanceID == "" || req.RequestShards <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		if err := o.client.Set(ctx, heartbeatKeyPrefix+req.InstanceID, time.Now().Unix(), heartbeatTTL).Err(); err != nil {
+			o.logger.Error("error recording heartbeat on register",
+				zap.Error(err),
+				zap.String("instanceID", req.InstanceID))
+		}
+
+		shardIDs, err := o.leaseShards(ctx, req.InstanceID, req.RequestShards)
+		if err != nil {
+			o.logger.Error("error leasing shards",
+				zap.Error(err),
+				zap.String("instanceID", req.InstanceID))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		o.mu.Lock()
+		resp := RegisterResponse{ShardIDs: shardIDs, ShardCount: o.shardCount}
+		o.mu.Unlock()
+
+		o.logger.Info("leased shards to instance",
+			zap.String("instanceID", req.InstanceID),
+			zap.Ints("shardIDs", shardIDs),
+		)
+
+		byt, err := json.Marshal(resp)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(byt)
+	}
+}
+
+func (o *Orchestrator) heartbeatHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req HeartbeatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.InstanceID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err := o.client.Set(r.Context(), heartbeatKeyPrefix+req.InstanceID, time.Now().Unix(), heartbeatTTL).Err()
+		if err != nil {
+			o.logger.Error("error recording heartbeat",
+				zap.Error(err),
+				zap.String("instanceID", req.InstanceID))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// reshardHandler drops the current shard -> instance assignment entirely and
+// publishes on ReshardChannel, which every running instance subscribes to
+// (see Galactus's watchForReshard) so it can drop its now-stale lease and
+// re-register, rather than sitting on shards the orchestrator no longer
+// thinks it owns. Used when the fleet's worker count or Discord's
+// recommended shard count changes.
+func (o *Orchestrator) reshardHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := o.refreshShardCount(); err != nil {
+			o.logger.Error("error refreshing shard count during reshard",
+				zap.Error(err))
+		}
+
+		if err := o.client.Del(r.Context(), leaseHashKey).Err(); err != nil {
+			o.logger.Error("error clearing shard leases during reshard",
+				zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if err := o.client.Publish(r.Context(), ReshardChannel, "reshard").Err(); err != nil {
+			o.logger.Error("error publishing reshard notification; running instances won't find out until their next restart",
+				zap.Error(err))
+		}
+
+		o.logger.Info("reshard triggered; all leases cleared")
+		w.WriteHeader(http.StatusOK)
+	}
+}
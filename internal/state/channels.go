@@ -0,0 +1,47 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// UpsertChannel records a channel from a CHANNEL_CREATE or CHANNEL_UPDATE
+// event.
+func (s *Store) UpsertChannel(ctx context.Context, guildID string, ch *discordgo.Channel) error {
+	byt, err := json.Marshal(ch)
+	if err != nil {
+		return err
+	}
+	if err := s.client.HSet(ctx, channelsKey(guildID), ch.ID, byt).Err(); err != nil {
+		return err
+	}
+	return s.touch(ctx, guildID)
+}
+
+// DeleteChannel removes a channel from the cache on a CHANNEL_DELETE event.
+func (s *Store) DeleteChannel(ctx context.Context, guildID, channelID string) error {
+	if err := s.client.HDel(ctx, channelsKey(guildID), channelID).Err(); err != nil {
+		return err
+	}
+	return s.touch(ctx, guildID)
+}
+
+// GetChannel returns the cached channel, or found=false if it's not cached.
+func (s *Store) GetChannel(ctx context.Context, guildID, channelID string) (channel *discordgo.Channel, found bool, err error) {
+	raw, err := s.client.HGet(ctx, channelsKey(guildID), channelID).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ch discordgo.Channel
+	if err := json.Unmarshal([]byte(raw), &ch); err != nil {
+		return nil, false, err
+	}
+	return &ch, true, nil
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// UpsertGuildSnapshot seeds a guild's entire cached state from a GUILD_CREATE
+// payload, which is the only gateway event carrying channels, members, and
+// voice states in bulk. Each category hash is replaced wholesale rather than
+// merged, since the snapshot is authoritative.
+func (s *Store) UpsertGuildSnapshot(ctx context.Context, guild *discordgo.Guild) error {
+	pipe := s.client.Pipeline()
+
+	guildByt, err := json.Marshal(guild)
+	if err != nil {
+		return err
+	}
+	pipe.HSet(ctx, metaKey(guild.ID), fieldGuild, guildByt)
+
+	pipe.Del(ctx, channelsKey(guild.ID))
+	for _, ch := range guild.Channels {
+		byt, err := json.Marshal(ch)
+		if err != nil {
+			return err
+		}
+		pipe.HSet(ctx, channelsKey(guild.ID), ch.ID, byt)
+	}
+
+	pipe.Del(ctx, rolesKey(guild.ID))
+	for _, role := range guild.Roles {
+		byt, err := json.Marshal(role)
+		if err != nil {
+			return err
+		}
+		pipe.HSet(ctx, rolesKey(guild.ID), role.ID, byt)
+	}
+
+	pipe.Del(ctx, membersKey(guild.ID))
+	for _, m := range guild.Members {
+		byt, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		pipe.HSet(ctx, membersKey(guild.ID), m.User.ID, byt)
+	}
+
+	pipe.Del(ctx, voiceStatesKey(guild.ID))
+	for _, vs := range guild.VoiceStates {
+		byt, err := json.Marshal(vs)
+		if err != nil {
+			return err
+		}
+		pipe.HSet(ctx, voiceStatesKey(guild.ID), vs.UserID, byt)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	return s.touch(ctx, guild.ID)
+}
+
+// UpdateGuildMeta refreshes a guild's cached metadata and roles from a
+// GUILD_UPDATE payload, which doesn't carry channels, members, or voice
+// states, so those hashes are left untouched.
+func (s *Store) UpdateGuildMeta(ctx context.Context, guild *discordgo.Guild) error {
+	pipe := s.client.Pipeline()
+
+	guildByt, err := json.Marshal(guild)
+	if err != nil {
+		return err
+	}
+	pipe.HSet(ctx, metaKey(guild.ID), fieldGuild, guildByt)
+
+	pipe.Del(ctx, rolesKey(guild.ID))
+	for _, role := range guild.Roles {
+		byt, err := json.Marshal(role)
+		if err != nil {
+			return err
+		}
+		pipe.HSet(ctx, rolesKey(guild.ID), role.ID, byt)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	return s.touch(ctx, guild.ID)
+}
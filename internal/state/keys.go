@@ -0,0 +1,30 @@
+package state
+
+const (
+	keyPrefix = "state:guild:"
+
+	metaSuffix        = ":meta"
+	channelsSuffix    = ":channels"
+	rolesSuffix       = ":roles"
+	membersSuffix     = ":members"
+	voiceStatesSuffix = ":voice_states"
+
+	fieldVersion = "version"
+	fieldGuild   = "guild"
+	fieldCold    = "cold"
+)
+
+func metaKey(guildID string) string        { return keyPrefix + guildID + metaSuffix }
+func channelsKey(guildID string) string    { return keyPrefix + guildID + channelsSuffix }
+func rolesKey(guildID string) string       { return keyPrefix + guildID + rolesSuffix }
+func membersKey(guildID string) string     { return keyPrefix + guildID + membersSuffix }
+func voiceStatesKey(guildID string) string { return keyPrefix + guildID + voiceStatesSuffix }
+func guildKeys(guildID string) []string {
+	return []string{
+		metaKey(guildID),
+		channelsKey(guildID),
+		rolesKey(guildID),
+		membersKey(guildID),
+		voiceStatesKey(guildID),
+	}
+}
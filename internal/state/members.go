@@ -0,0 +1,48 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// UpsertMember records a member from a GUILD_MEMBER_ADD or
+// GUILD_MEMBER_UPDATE event.
+func (s *Store) UpsertMember(ctx context.Context, guildID string, m *discordgo.Member) error {
+	byt, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := s.client.HSet(ctx, membersKey(guildID), m.User.ID, byt).Err(); err != nil {
+		return err
+	}
+	return s.touch(ctx, guildID)
+}
+
+// DeleteMember removes a member from the cache on a GUILD_MEMBER_REMOVE
+// event.
+func (s *Store) DeleteMember(ctx context.Context, guildID, userID string) error {
+	if err := s.client.HDel(ctx, membersKey(guildID), userID).Err(); err != nil {
+		return err
+	}
+	return s.touch(ctx, guildID)
+}
+
+// GetMember returns the cached member, or found=false if it's not cached.
+func (s *Store) GetMember(ctx context.Context, guildID, userID string) (member *discordgo.Member, found bool, err error) {
+	raw, err := s.client.HGet(ctx, membersKey(guildID), userID).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var m discordgo.Member
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		return nil, false, err
+	}
+	return &m, true, nil
+}
@@ -0,0 +1,85 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// coldTTL is how long a guild's cached state is kept once it's been
+	// flagged cold (e.g. a GUILD_DELETE outage) before Redis reclaims it.
+	coldTTL = 10 * time.Minute
+	// evictTTL is how long an actively-updated guild's state survives
+	// without a fresh event before Redis reclaims it outright.
+	evictTTL = 24 * time.Hour
+)
+
+// Store maintains a versioned, gateway-derived snapshot of guild state
+// (guild metadata, channels, roles, members, voice states) in Redis, keyed
+// by guild ID. It lets consumers (the bot worker, REST proxy handlers) read
+// cached Discord data without re-deriving it from the gateway or hitting
+// Discord's REST API on every request.
+type Store struct {
+	client *redis.Client
+	logger *zap.Logger
+}
+
+func NewStore(logger *zap.Logger, client *redis.Client) *Store {
+	return &Store{
+		client: client,
+		logger: logger,
+	}
+}
+
+// touch bumps a guild's version, clears its cold flag, and refreshes the
+// eviction TTL on every key that makes up its state. It's called after
+// every write so that a guild stays live for as long as the gateway keeps
+// sending events for it.
+func (s *Store) touch(ctx context.Context, guildID string) error {
+	pipe := s.client.Pipeline()
+	pipe.HIncrBy(ctx, metaKey(guildID), fieldVersion, 1)
+	pipe.HSet(ctx, metaKey(guildID), fieldCold, "0")
+	for _, key := range guildKeys(guildID) {
+		pipe.Expire(ctx, key, evictTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// MarkCold flags a guild's cached state as stale (e.g. on a GUILD_DELETE
+// outage, rather than a real removal) and shortens its TTL to coldTTL, so
+// a reader knows to lazily re-fetch from Discord's REST API instead of
+// trusting the cache, and the entry naturally expires if the guild never
+// comes back.
+func (s *Store) MarkCold(ctx context.Context, guildID string) error {
+	pipe := s.client.Pipeline()
+	pipe.HSet(ctx, metaKey(guildID), fieldCold, "1")
+	for _, key := range guildKeys(guildID) {
+		pipe.Expire(ctx, key, coldTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IsCold reports whether guildID's cached state is stale and due for a
+// lazy REST re-fetch. A guild Galactus has never cached is reported cold.
+func (s *Store) IsCold(ctx context.Context, guildID string) (bool, error) {
+	val, err := s.client.HGet(ctx, metaKey(guildID), fieldCold).Result()
+	if err == redis.Nil {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return val == "1", nil
+}
+
+// DeleteGuild drops all cached state for guildID outright. Used when the
+// guild is actually removed (the bot was kicked or the guild was deleted),
+// as opposed to a transient outage, which should use MarkCold instead.
+func (s *Store) DeleteGuild(ctx context.Context, guildID string) error {
+	return s.client.Del(ctx, guildKeys(guildID)...).Err()
+}
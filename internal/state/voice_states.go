@@ -0,0 +1,41 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// UpsertVoiceState records a member's voice state from a VOICE_STATE_UPDATE
+// event. A user leaving voice entirely arrives with an empty ChannelID
+// rather than a separate delete event, so we always upsert.
+func (s *Store) UpsertVoiceState(ctx context.Context, vs *discordgo.VoiceState) error {
+	byt, err := json.Marshal(vs)
+	if err != nil {
+		return err
+	}
+	if err := s.client.HSet(ctx, voiceStatesKey(vs.GuildID), vs.UserID, byt).Err(); err != nil {
+		return err
+	}
+	return s.touch(ctx, vs.GuildID)
+}
+
+// GetVoiceState returns the cached voice state, or found=false if it's not
+// cached.
+func (s *Store) GetVoiceState(ctx context.Context, guildID, userID string) (voiceState *discordgo.VoiceState, found bool, err error) {
+	raw, err := s.client.HGet(ctx, voiceStatesKey(guildID), userID).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var vs discordgo.VoiceState
+	if err := json.Unmarshal([]byte(raw), &vs); err != nil {
+		return nil, false, err
+	}
+	return &vs, true, nil
+}
@@ -0,0 +1,16 @@
+package discord_message
+
+// DiscordMessageType identifies the kind of payload queued through
+// PushDiscordMessage, so the worker popping a job off Redis knows how to
+// unmarshal and dispatch it.
+type DiscordMessageType int
+
+const (
+	VoiceStateUpdate DiscordMessageType = iota
+	Interaction
+)
+
+var DiscordMessageTypeStrings = map[DiscordMessageType]string{
+	VoiceStateUpdate: "VoiceStateUpdate",
+	Interaction:      "Interaction",
+}
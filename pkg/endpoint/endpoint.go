@@ -0,0 +1,18 @@
+package endpoint
+
+// Routes added alongside gateway-derived state tracking, shard supervision,
+// and Discord interactions support. These are additive to whatever
+// REST-proxy route constants already live in this package; they intentionally
+// don't redeclare any of those to avoid colliding with them.
+const (
+	ShardsStatus = "/shards/status"
+
+	GetStateChannel    = "/state/guild/{guildID}/channel/{channelID}"
+	GetStateMember     = "/state/guild/{guildID}/member/{userID}"
+	GetStateVoiceState = "/state/guild/{guildID}/voice/{userID}"
+
+	Interactions                    = "/interactions"
+	CreateInteractionResponse       = "/interactions/respond"
+	EditOriginalInteractionResponse = "/interactions/edit-original"
+	CreateFollowupMessage           = "/interactions/followup"
+)